@@ -1,51 +1,84 @@
 package govault
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
 )
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
-// checkAuthNeeded is called after an issue occurs and is an attempt to retry getting a new token (if needed)
+// checkAuthNeeded is called after an issue occurs and is an attempt to retry getting a new token
+// (if needed). It returns nil only when a login happened while it waited (by this caller or one
+// queued ahead of it), so callers retry their request only then; if the token was still good, the
+// original error was unrelated to auth and an error is returned instead.
 func (v *API) checkAuthNeeded() error {
-	if time.Now().Unix()-v.authCache.lastAuthTime < 5 {
-		return errors.New("login was attempted recently, not retrying")
+	v.mu.RLock()
+	before := v.lastAuthTime
+	v.mu.RUnlock()
+
+	if err := v.reauthLocked(context.Background(), false); err != nil {
+		return err
 	}
 
-	if !v.tokenRefreshNeeded(5) {
-		// This means there was an error, but it was unrelated to auth
+	v.mu.RLock()
+	after := v.lastAuthTime
+	v.mu.RUnlock()
+
+	if after == before {
 		return errors.New("vault token does not need refreshing")
 	}
 
-	switch v.authCache.lastAuthMethod {
-	case "approle":
-		if v.authCache.approle.roleIDFile == "" || v.authCache.approle.secretIDFile == "" {
-			_, err := v.ApproleLogin(v.authCache.approle.roleID, v.authCache.approle.secretID, v.authCache.approle.authPath)
-			return err
-		}
-		_, err := v.InitApprole(v.authCache.approle.roleIDFile, v.authCache.approle.secretIDFile, v.authCache.approle.tokenFile, v.authCache.approle.authPath)
-		return err
-	case "kubernetes":
-		_, err := v.KubernetesLogin(v.authCache.kubernetes.jwt, v.authCache.kubernetes.role, v.authCache.kubernetes.authPath)
-		return err
-	case "ldap":
-		_, err := v.LDAPLogin(v.authCache.ldap.username, v.authCache.ldap.password, v.authCache.ldap.authPath)
-		return err
-	case "token": // This is for testing only / local since there's no point in re-authenticating if all you have is a token.
-		// If we get here, it means the token they provided is expiring soon (or has expired). The only thing we can is try to renew it but it probably won't work.
-		// @ TODO Add the option to renew a token, and when they set the auth method as a token, we should change the tokenRefreshNeeded time to longer for a better change of catching it.
-		//v.Client.Auth().Token().RenewSelf(1) ???
-		v.authCache.lastAuthTime = time.Now().Unix()
+	return nil
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// reauthLocked does the actual work for checkAuthNeeded and reauth. It holds v.mu for its entire
+// body, so concurrent callers queue up behind whichever goroutine gets there first instead of each
+// independently racing to re-authenticate: by the time a queued caller acquires the lock, the token
+// has either already been refreshed by the caller that got there first (in which case this caller
+// just sees it as no longer needing refresh) or it's still stale and this caller attempts its own
+// login, gated by the recently-attempted cooldown below. force skips both the TTL check and that
+// cooldown and always logs in again, for the auto-renew loop, which already paces itself. ctx is passed through to the Authenticator so a
+// caller such as the auto-renew loop can have a hung login call abandoned on Stop instead of
+// blocking it for the full client timeout.
+func (v *API) reauthLocked(ctx context.Context, force bool) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !force && !v.tokenRefreshNeeded(5) {
 		return nil
-	default:
-		return fmt.Errorf("unknown previous auth method or no authentication performed: [%s]", v.authCache.lastAuthMethod)
 	}
 
+	if !force && time.Now().Unix()-v.lastAuthTime < 5 {
+		return errors.New("login was attempted recently, not retrying")
+	}
+
+	if v.authenticator == nil {
+		return errors.New("unknown previous auth method or no authentication performed")
+	}
+
+	if v.authenticator.Name() == "token" {
+		// This is for testing only / local since there's no point in re-authenticating if all you
+		// have is a token. If we get here, it means the token they provided is expiring soon (or
+		// has expired), and a bare token can't log itself back in.
+		v.lastAuthTime = time.Now().Unix()
+		return nil
+	}
+
+	secret, err := v.authenticator.Login(ctx, v.Client)
+	if err != nil {
+		return err
+	}
+
+	return v.applyLoginLocked(secret)
 }
 
 /* ----------------------------------------------------------------------------------------------------------------- */
@@ -93,158 +126,162 @@ func (v *API) RevokeSelf() error {
 
 // SetToken is a shorthand for .Client.SetToken and is really only used for debugging to set up some test variables. You shouldn't use this over a real auth method.
 func (v *API) SetToken(token string) {
-	v.authCache.lastAuthMethod = "token"
-	v.authCache.lastAuthTime = time.Now().Unix()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.authenticator = &tokenAuthenticator{}
+	v.lastAuthTime = time.Now().Unix()
 	v.Client.SetToken(token)
 }
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
-// InitApprole attempts to obtain a valid Vault token via approle or local cache
-func (v *API) InitApprole(roleIDFile string, secretIDFile string, tokenFile string, authPath string) (string, error) {
-	var vaultToken string
+// Login authenticates using a caller-supplied Authenticator, letting downstream users plug in
+// auth methods this package doesn't implement itself (e.g. AWS IAM, cert) by implementing
+// Authenticator themselves, without needing changes here.
+func (v *API) Login(authenticator Authenticator) (string, error) {
+	return v.login(context.Background(), authenticator)
+}
 
-	if authPath != "" {
-		v.authCache.approle.authPath = authPath
-	}
+/* ----------------------------------------------------------------------------------------------------------------- */
 
-	// We save this stuff for automatic re-auth later
-	v.authCache.approle.roleIDFile = roleIDFile
-	v.authCache.approle.secretIDFile = secretIDFile
-	v.authCache.approle.tokenFile = tokenFile
-	v.authCache.lastAuthMethod = "approle"
+// login runs authenticator against v.Client, applies the resulting token, and remembers
+// authenticator so checkAuthNeeded can call it again later. It holds v.mu for the same
+// singleflight reason checkAuthNeeded does: only one login should be in flight against v at a
+// time, with everyone else blocking on the mutex until the new token is set.
+func (v *API) login(ctx context.Context, authenticator Authenticator) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	// Load Vault secret-id from file
-	roleIDTmp, err := os.ReadFile(v.authCache.approle.roleIDFile)
+	secret, err := authenticator.Login(ctx, v.Client)
 	if err != nil {
-		return "", fmt.Errorf("error occurred while trying to read role-id from file: %s", v.authCache.approle.roleIDFile)
+		return "", err
 	}
-	roleID := strings.TrimSuffix(string(roleIDTmp), "\n")
 
-	// Load Vault secret-id from file
-	secretIDTmp, err := os.ReadFile(v.authCache.approle.secretIDFile)
-	if err != nil {
-		return "", fmt.Errorf("error occurred while trying to read secret-id from file: %s", v.authCache.approle.secretIDFile)
+	if err := v.applyLoginLocked(secret); err != nil {
+		return "", err
 	}
-	secretID := strings.TrimSuffix(string(secretIDTmp), "\n")
 
-	// Attempt to read token from file cache
-	tokenTmp, _ := os.ReadFile(v.authCache.approle.tokenFile)
-
-	vaultToken = strings.TrimSuffix(string(tokenTmp), "\n")
-	v.Client.SetToken(vaultToken)
-
-	if vaultToken == "" || v.tokenRefreshNeeded(5) {
-		vaultToken, err = v.ApproleLogin(roleID, secretID, v.authCache.approle.authPath)
-		if err != nil {
-			return "", fmt.Errorf("error attempting approle login: %s", err)
-		}
+	v.authenticator = authenticator
+	return secret.Auth.ClientToken, nil
+}
 
-		// Let's save the token for next time
-		tokenHwnd, err := os.Create(v.authCache.approle.tokenFile)
-		if err != nil {
-			return "", fmt.Errorf("unable to create vault token file: %s", v.authCache.approle.tokenFile)
-		}
+/* ----------------------------------------------------------------------------------------------------------------- */
 
-		defer tokenHwnd.Close()
-		_, err = tokenHwnd.WriteString(vaultToken)
-		if err != nil {
-			return "", fmt.Errorf("unable to write to vault token file: %s", v.authCache.approle.tokenFile)
-		}
+// applyLoginLocked stores the token from a successful login and records when it happened. Callers
+// must already hold v.mu.
+func (v *API) applyLoginLocked(secret *vaultapi.Secret) error {
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("no auth info returned")
 	}
 
-	return vaultToken, nil
+	v.lastAuthTime = time.Now().Unix()
+	v.Client.SetToken(secret.Auth.ClientToken)
+	return nil
 }
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
-// ApproleLogin logins in using role-id and secret-id and returns a token if successful
-func (v *API) ApproleLogin(roleID string, secretID string, authPath string) (string, error) {
-	data := map[string]interface{}{
-		"role_id":   roleID,
-		"secret_id": secretID,
+// InitApprole attempts to obtain a valid Vault token via approle or local cache
+func (v *API) InitApprole(roleIDFile string, secretIDFile string, tokenFile string, authPath string) (string, error) {
+	authenticator := &approleFileAuthenticator{
+		roleIDFile:   roleIDFile,
+		secretIDFile: secretIDFile,
+		tokenFile:    tokenFile,
+		authPath:     authPath,
 	}
 
-	// Sane default
-	if authPath == "" {
-		authPath = "auth/approle"
+	// Load Vault role-id from file
+	if _, err := os.ReadFile(roleIDFile); err != nil {
+		return "", fmt.Errorf("error occurred while trying to read role-id from file: %s", roleIDFile)
 	}
 
-	v.authCache.approle.roleID = roleID
-	v.authCache.approle.secretID = secretID
-	v.authCache.lastAuthMethod = "approle"
-	v.authCache.approle.authPath = authPath
+	// Load Vault secret-id from file
+	if _, err := os.ReadFile(secretIDFile); err != nil {
+		return "", fmt.Errorf("error occurred while trying to read secret-id from file: %s", secretIDFile)
+	}
 
-	secret, err := v.Client.Logical().Write(fmt.Sprintf("%s/login", authPath), data)
-	if err != nil {
-		return "", err
+	// Attempt to read token from file cache
+	tokenTmp, _ := os.ReadFile(tokenFile)
+	vaultToken := strings.TrimSuffix(string(tokenTmp), "\n")
+	v.mu.Lock()
+	v.Client.SetToken(vaultToken)
+	v.mu.Unlock()
+
+	if vaultToken != "" && !v.tokenRefreshNeeded(5) {
+		v.mu.Lock()
+		v.authenticator = authenticator
+		v.lastAuthTime = time.Now().Unix()
+		v.mu.Unlock()
+		return vaultToken, nil
 	}
 
-	if secret.Auth == nil {
-		return "", fmt.Errorf("no auth info returned")
+	vaultToken, err := v.login(context.Background(), authenticator)
+	if err != nil {
+		return "", fmt.Errorf("error attempting approle login: %s", err)
 	}
 
-	v.authCache.lastAuthTime = time.Now().Unix()
-	v.Client.SetToken(secret.Auth.ClientToken)
-	return secret.Auth.ClientToken, nil
+	return vaultToken, nil
 }
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
-// KubernetesLogin uses the service token (JWT) to authenticate to a vault role
-func (v *API) KubernetesLogin(jwt string, role string, authPath string) (string, error) {
-	data := map[string]interface{}{
-		"jwt":  jwt,
-		"role": role,
-	}
+// ApproleOptions configures ApproleLoginWithOptions, letting callers source the secret_id from a
+// literal value, a file, an environment variable, or a Vault response-wrapping token.
+type ApproleOptions struct {
+	RoleID   string
+	SecretID string
 
-	v.authCache.lastAuthMethod = "kubernetes"
-	v.authCache.kubernetes.jwt = jwt
-	v.authCache.kubernetes.role = role
-	v.authCache.kubernetes.authPath = authPath
+	// SecretIDFile, if set, is read to obtain the secret_id and takes priority over SecretID.
+	SecretIDFile string
 
-	secret, err := v.Client.Logical().Write(authPath, data)
-	if err != nil {
-		return "", err
-	}
+	// SecretIDEnv, if set, names an environment variable to obtain the secret_id from, and takes
+	// priority over SecretIDFile.
+	SecretIDEnv string
 
-	if secret.Auth == nil {
-		return "", fmt.Errorf("no auth info returned")
-	}
+	// IsWrappingToken indicates that the resolved secret_id value is itself a Vault response-
+	// wrapping token that must be unwrapped before use.
+	IsWrappingToken bool
 
-	v.authCache.lastAuthTime = time.Now().Unix()
-	v.Client.SetToken(secret.Auth.ClientToken)
-	return secret.Auth.ClientToken, nil
+	AuthPath string
 }
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
-// LDAPLogin authenticates to Vault via LDAP
-func (v *API) LDAPLogin(username string, password string, authPath string) (string, error) {
-	data := map[string]interface{}{
-		"password": password,
-	}
+// ApproleLoginWithOptions logs in like ApproleLogin, but resolves the secret_id from opts (a
+// literal value, a file, an environment variable, or a Vault response-wrapping token) first. This
+// matches the standard Vault response-wrapping pattern for secure secret_id distribution to
+// workloads.
+func (v *API) ApproleLoginWithOptions(opts ApproleOptions) (string, error) {
+	return v.login(context.Background(), &approleAuthenticator{opts: opts})
+}
 
-	if authPath == "" {
-		authPath = "auth/ldap"
-	}
+/* ----------------------------------------------------------------------------------------------------------------- */
 
-	v.authCache.lastAuthMethod = "ldap"
-	v.authCache.ldap.username = username
-	v.authCache.ldap.password = password
-	v.authCache.ldap.authPath = authPath
+// ApproleLogin logins in using role-id and secret-id and returns a token if successful
+func (v *API) ApproleLogin(roleID string, secretID string, authPath string) (string, error) {
+	return v.ApproleLoginWithOptions(ApproleOptions{RoleID: roleID, SecretID: secretID, AuthPath: authPath})
+}
 
-	// default would look like auth/ldap/login/${username}
-	secret, err := v.Client.Logical().Write(fmt.Sprintf("%s/login/%s", authPath, username), data)
-	if err != nil {
-		return "", err
-	}
+/* ----------------------------------------------------------------------------------------------------------------- */
 
-	if secret.Auth == nil {
-		return "", fmt.Errorf("no auth info returned")
-	}
+// KubernetesLogin uses the service token (JWT) to authenticate to a vault role
+func (v *API) KubernetesLogin(jwt string, role string, authPath string) (string, error) {
+	return v.login(context.Background(), &kubernetesAuthenticator{jwt: jwt, role: role, authPath: authPath})
+}
 
-	v.authCache.lastAuthTime = time.Now().Unix()
-	v.Client.SetToken(secret.Auth.ClientToken)
-	return secret.Auth.ClientToken, nil
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// KubernetesLoginFromPath authenticates like KubernetesLogin, but auto-reads the service account
+// JWT from tokenPath on every login (including re-auth), so a rotated projected token is picked
+// up without the caller having to re-read and re-supply it.
+func (v *API) KubernetesLoginFromPath(tokenPath string, role string, authPath string) (string, error) {
+	return v.login(context.Background(), &kubernetesAuthenticator{jwtFile: tokenPath, role: role, authPath: authPath})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// LDAPLogin authenticates to Vault via LDAP
+func (v *API) LDAPLogin(username string, password string, authPath string) (string, error) {
+	return v.login(context.Background(), &ldapAuthenticator{username: username, password: password, authPath: authPath})
 }
@@ -0,0 +1,266 @@
+package govault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Authenticator is the pluggable interface behind every login method API supports. checkAuthNeeded
+// simply calls Login again against whichever Authenticator was last used, instead of switching on
+// a hard-coded auth method name. Downstream users can implement this interface to register
+// additional methods (e.g. AWS IAM, cert) without needing changes here, then hand their
+// implementation to API.Login.
+type Authenticator interface {
+	// Login authenticates against client and returns the resulting auth secret.
+	Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error)
+
+	// Name identifies the authentication method, e.g. for logging or error messages.
+	Name() string
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// approleAuthenticator logs in via AppRole, resolving its secret_id the way opts describes
+// (literal value, file, environment variable, or response-wrapping token) on every login.
+type approleAuthenticator struct {
+	opts ApproleOptions
+}
+
+func (a *approleAuthenticator) Name() string { return "approle" }
+
+func (a *approleAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	secretID, err := resolveApproleSecretID(ctx, client, a.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	authPath := a.opts.AuthPath
+	if authPath == "" {
+		authPath = "auth/approle"
+	}
+
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/login", authPath), map[string]interface{}{
+		"role_id":   a.opts.RoleID,
+		"secret_id": secretID,
+	})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// resolveApproleSecretID resolves the secret_id value from opts, reading it from an environment
+// variable or file if configured, then unwrapping it via sys/wrapping/unwrap if IsWrappingToken
+// is set.
+func resolveApproleSecretID(ctx context.Context, client *vaultapi.Client, opts ApproleOptions) (string, error) {
+	secretID := opts.SecretID
+
+	switch {
+	case opts.SecretIDEnv != "":
+		secretID = os.Getenv(opts.SecretIDEnv)
+	case opts.SecretIDFile != "":
+		secretIDTmp, err := os.ReadFile(opts.SecretIDFile)
+		if err != nil {
+			return "", fmt.Errorf("error occurred while trying to read secret-id from file: %s", opts.SecretIDFile)
+		}
+		secretID = strings.TrimSuffix(string(secretIDTmp), "\n")
+	}
+
+	if !opts.IsWrappingToken {
+		return secretID, nil
+	}
+
+	unwrapped, err := client.Logical().UnwrapWithContext(ctx, secretID)
+	if err != nil {
+		return "", fmt.Errorf("error occurred while unwrapping secret-id: %s", err)
+	}
+
+	if unwrapped == nil {
+		return "", fmt.Errorf("wrapping token did not unwrap to a secret")
+	}
+
+	wrappedSecretID, ok := unwrapped.Data["secret_id"].(string)
+	if !ok {
+		return "", fmt.Errorf("unwrapped secret did not contain a secret_id")
+	}
+
+	return wrappedSecretID, nil
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// approleFileAuthenticator logs in via AppRole like approleAuthenticator, but re-reads the
+// role-id/secret-id from files on every login (as InitApprole has always done) and caches the
+// resulting token to tokenFile.
+type approleFileAuthenticator struct {
+	roleIDFile   string
+	secretIDFile string
+	tokenFile    string
+	authPath     string
+}
+
+func (a *approleFileAuthenticator) Name() string { return "approle" }
+
+func (a *approleFileAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	roleIDTmp, err := os.ReadFile(a.roleIDFile)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while trying to read role-id from file: %s", a.roleIDFile)
+	}
+	roleID := strings.TrimSuffix(string(roleIDTmp), "\n")
+
+	secretIDTmp, err := os.ReadFile(a.secretIDFile)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while trying to read secret-id from file: %s", a.secretIDFile)
+	}
+	secretID := strings.TrimSuffix(string(secretIDTmp), "\n")
+
+	authPath := a.authPath
+	if authPath == "" {
+		authPath = "auth/approle"
+	}
+
+	secret, err := client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/login", authPath), map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": secretID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if secret != nil && secret.Auth != nil && a.tokenFile != "" {
+		if err := os.WriteFile(a.tokenFile, []byte(secret.Auth.ClientToken), 0o600); err != nil {
+			return nil, fmt.Errorf("unable to write to vault token file: %s", a.tokenFile)
+		}
+	}
+
+	return secret, nil
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// kubernetesAuthenticator logs in via the Kubernetes auth method, auto-reading the JWT from jwtFile
+// on every login if jwtFile is set (in which case jwt is ignored), or using jwt directly otherwise.
+type kubernetesAuthenticator struct {
+	jwt      string
+	jwtFile  string
+	role     string
+	authPath string
+}
+
+func (a *kubernetesAuthenticator) Name() string { return "kubernetes" }
+
+func (a *kubernetesAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	jwt := a.jwt
+
+	if a.jwtFile != "" {
+		jwtTmp, err := os.ReadFile(a.jwtFile)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while trying to read kubernetes service account token from file: %s", a.jwtFile)
+		}
+		jwt = strings.TrimSuffix(string(jwtTmp), "\n")
+	}
+
+	return client.Logical().WriteWithContext(ctx, a.authPath, map[string]interface{}{
+		"jwt":  jwt,
+		"role": a.role,
+	})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// ldapAuthenticator logs in via the LDAP auth method.
+type ldapAuthenticator struct {
+	username string
+	password string
+	authPath string
+}
+
+func (a *ldapAuthenticator) Name() string { return "ldap" }
+
+func (a *ldapAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	authPath := a.authPath
+	if authPath == "" {
+		authPath = "auth/ldap"
+	}
+
+	// default would look like auth/ldap/login/${username}
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/login/%s", authPath, a.username), map[string]interface{}{
+		"password": a.password,
+	})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// jwtAuthenticator logs in via the JWT/OIDC auth method using a pre-issued JWT (role-based, not
+// the browser-redirect OIDC flow).
+type jwtAuthenticator struct {
+	jwt      string
+	role     string
+	authPath string
+}
+
+func (a *jwtAuthenticator) Name() string { return "jwt" }
+
+func (a *jwtAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	authPath := a.authPath
+	if authPath == "" {
+		authPath = "auth/jwt"
+	}
+
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/login", authPath), map[string]interface{}{
+		"role": a.role,
+		"jwt":  a.jwt,
+	})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// JWTLogin authenticates to Vault using the JWT/OIDC auth method with a pre-issued JWT.
+func (v *API) JWTLogin(jwt string, role string, authPath string) (string, error) {
+	return v.login(context.Background(), &jwtAuthenticator{jwt: jwt, role: role, authPath: authPath})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// userpassAuthenticator logs in via the userpass auth method.
+type userpassAuthenticator struct {
+	username string
+	password string
+	authPath string
+}
+
+func (a *userpassAuthenticator) Name() string { return "userpass" }
+
+func (a *userpassAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	authPath := a.authPath
+	if authPath == "" {
+		authPath = "auth/userpass"
+	}
+
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/login/%s", authPath, a.username), map[string]interface{}{
+		"password": a.password,
+	})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// UserpassLogin authenticates to Vault using the userpass auth method.
+func (v *API) UserpassLogin(username string, password string, authPath string) (string, error) {
+	return v.login(context.Background(), &userpassAuthenticator{username: username, password: password, authPath: authPath})
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// tokenAuthenticator represents a bare token with no underlying login method to re-run. It's set
+// by SetToken and checked for by name in checkAuthNeeded, which treats it as non-renewable.
+type tokenAuthenticator struct{}
+
+func (a *tokenAuthenticator) Name() string { return "token" }
+
+func (a *tokenAuthenticator) Login(ctx context.Context, client *vaultapi.Client) (*vaultapi.Secret, error) {
+	return nil, fmt.Errorf("token auth method cannot be re-logged in; use StartAutoRenew or RenewSelf instead")
+}
@@ -0,0 +1,165 @@
+package govault
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// defaultKubernetesTokenPath is where the Kubernetes service account JWT is mounted by default.
+const defaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Config holds the settings NewFromConfig needs to point an API at a Vault cluster and perform
+// its initial login. NewFromEnv builds one of these from the standard VAULT_* environment
+// variables.
+type Config struct {
+	Address    string
+	Namespace  string
+	CACert     string
+	SkipVerify bool
+
+	// AuthMethod selects the login performed by NewFromConfig: approle, kubernetes, ldap, or token.
+	AuthMethod    string
+	AuthMountPath string
+
+	RoleID       string
+	SecretID     string
+	SecretIDFile string
+
+	KubernetesRole      string
+	KubernetesTokenPath string
+
+	LDAPUsername string
+	LDAPPassword string
+
+	// Token is used directly when AuthMethod is "token".
+	Token string
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// NewFromEnv builds a Config from the standard VAULT_* environment variables (VAULT_ADDR,
+// VAULT_NAMESPACE, VAULT_AUTH_METHOD, VAULT_AUTH_MOUNT_PATH, VAULT_ROLE_ID/VAULT_SECRET_ID (or
+// their _FILE variants), VAULT_AUTH_KUBERNETES_ROLE, VAULT_AUTH_KUBERNETES_TOKEN_PATH, LDAP
+// credentials, VAULT_CACERT and VAULT_SKIP_VERIFY) and returns a ready API via NewFromConfig. This
+// makes govault drop-in usable in Kubernetes/CI environments without callers hand-wiring each auth
+// path.
+func NewFromEnv() (*API, error) {
+	cfg := Config{
+		Address:             os.Getenv("VAULT_ADDR"),
+		Namespace:           os.Getenv("VAULT_NAMESPACE"),
+		CACert:              os.Getenv("VAULT_CACERT"),
+		AuthMethod:          os.Getenv("VAULT_AUTH_METHOD"),
+		AuthMountPath:       os.Getenv("VAULT_AUTH_MOUNT_PATH"),
+		RoleID:              os.Getenv("VAULT_ROLE_ID"),
+		SecretID:            os.Getenv("VAULT_SECRET_ID"),
+		SecretIDFile:        os.Getenv("VAULT_SECRET_ID_FILE"),
+		KubernetesRole:      os.Getenv("VAULT_AUTH_KUBERNETES_ROLE"),
+		KubernetesTokenPath: os.Getenv("VAULT_AUTH_KUBERNETES_TOKEN_PATH"),
+		LDAPUsername:        os.Getenv("VAULT_LDAP_USERNAME"),
+		LDAPPassword:        os.Getenv("VAULT_LDAP_PASSWORD"),
+		Token:               os.Getenv("VAULT_TOKEN"),
+	}
+
+	if roleIDFile := os.Getenv("VAULT_ROLE_ID_FILE"); roleIDFile != "" {
+		roleIDTmp, err := os.ReadFile(roleIDFile)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred while trying to read role-id from file: %s", roleIDFile)
+		}
+		cfg.RoleID = strings.TrimSuffix(string(roleIDTmp), "\n")
+	}
+
+	if cfg.KubernetesTokenPath == "" {
+		cfg.KubernetesTokenPath = defaultKubernetesTokenPath
+	}
+
+	if skipVerify := os.Getenv("VAULT_SKIP_VERIFY"); skipVerify != "" {
+		parsed, err := strconv.ParseBool(skipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse VAULT_SKIP_VERIFY as a bool: %s", err)
+		}
+		cfg.SkipVerify = parsed
+	}
+
+	return NewFromConfig(cfg)
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// NewFromConfig builds an API pointed at cfg.Address (with TLS and namespace settings applied)
+// and performs the initial login for cfg.AuthMethod (approle, kubernetes, ldap, or token).
+func NewFromConfig(cfg Config) (*API, error) {
+	vaultAPIConfig := vaultapi.DefaultConfig()
+	vaultAPIConfig.Address = cfg.Address
+
+	if cfg.CACert != "" || cfg.SkipVerify {
+		if err := vaultAPIConfig.ConfigureTLS(&vaultapi.TLSConfig{
+			CACert:   cfg.CACert,
+			Insecure: cfg.SkipVerify,
+		}); err != nil {
+			return nil, fmt.Errorf("unable to configure TLS: %s", err)
+		}
+	}
+
+	client, err := vaultapi.NewClient(vaultAPIConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Namespace != "" {
+		client.SetNamespace(cfg.Namespace)
+	}
+
+	v := &API{Client: client}
+
+	switch cfg.AuthMethod {
+	case "approle":
+		authPath := cfg.AuthMountPath
+		if authPath == "" {
+			authPath = "auth/approle"
+		}
+
+		if cfg.SecretIDFile != "" {
+			secretIDTmp, err := os.ReadFile(cfg.SecretIDFile)
+			if err != nil {
+				return nil, fmt.Errorf("error occurred while trying to read secret-id from file: %s", cfg.SecretIDFile)
+			}
+			cfg.SecretID = strings.TrimSuffix(string(secretIDTmp), "\n")
+		}
+
+		if _, err := v.ApproleLogin(cfg.RoleID, cfg.SecretID, authPath); err != nil {
+			return nil, err
+		}
+	case "kubernetes":
+		authPath := cfg.AuthMountPath
+		if authPath == "" {
+			authPath = "auth/kubernetes/login"
+		}
+
+		if _, err := v.KubernetesLoginFromPath(cfg.KubernetesTokenPath, cfg.KubernetesRole, authPath); err != nil {
+			return nil, err
+		}
+	case "ldap":
+		authPath := cfg.AuthMountPath
+		if authPath == "" {
+			authPath = "auth/ldap"
+		}
+
+		if _, err := v.LDAPLogin(cfg.LDAPUsername, cfg.LDAPPassword, authPath); err != nil {
+			return nil, err
+		}
+	case "token":
+		v.SetToken(cfg.Token)
+	default:
+		return nil, fmt.Errorf("unknown or unset VAULT_AUTH_METHOD: [%s]", cfg.AuthMethod)
+	}
+
+	return v, nil
+}
@@ -3,6 +3,7 @@ package govault
 import (
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -29,6 +30,47 @@ func (v *API) SetURI(uri string) error {
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
+// SetNamespace sets the Vault Enterprise namespace used for all subsequent requests made through
+// this API's Client.
+func (v *API) SetNamespace(ns string) {
+	v.Client.SetNamespace(ns)
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// WithNamespace returns a shallow copy of v whose Client is scoped to the given Vault Enterprise
+// namespace, leaving v itself untouched. Callers do ns, err := v.WithNamespace("team-a") and then
+// ns.GetKV(...), including its re-auth flow, without disturbing the namespace used elsewhere.
+// Because it also returns an error, the result can't be chained directly in one expression.
+// Client.Clone() only carries the bearer token over when CloneToken is set on the
+// client's config, which nothing here does, so the token is copied across explicitly instead.
+func (v *API) WithNamespace(ns string) (*API, error) {
+	client, err := v.Client.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone vault client: %s", err)
+	}
+	client.SetNamespace(ns)
+	client.SetToken(v.Client.Token())
+
+	v.mu.RLock()
+	authenticator := v.authenticator
+	lastAuthTime := v.lastAuthTime
+	v.mu.RUnlock()
+
+	v.renewMu.Lock()
+	renew := v.renew
+	v.renewMu.Unlock()
+
+	return &API{
+		Client:        client,
+		authenticator: authenticator,
+		lastAuthTime:  lastAuthTime,
+		renew:         renew,
+	}, nil
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
 // Base64SmartDecode returns the original string if it's not base64 encoded, or the decoded version if it is.
 func (v *API) Base64SmartDecode(data string) string {
 	// If we can convert this to a raw int, we aren't treating it as base64
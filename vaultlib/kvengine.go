@@ -0,0 +1,163 @@
+package govault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// KVEngine wraps a single KV mount and transparently rewrites paths for whichever version (v1 or
+// kv-v2) that mount happens to be running, so callers no longer need to know the engine version
+// or pre-format paths for GetKV2ListPath/PutKV2/etc. themselves.
+type KVEngine struct {
+	v       *API
+	mount   string
+	version string
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// NewKVEngine returns a KVEngine for the given mount (e.g. "secret"), detecting whether it's kv
+// v1 or kv-v2 by reading the mount's options.version from sys/mounts. The detected version is
+// cached on v per mount, so two APIs pointed at different clusters (or the same cluster under
+// different namespaces) never share a cache entry for the same mount path.
+func NewKVEngine(v *API, mount string) (*KVEngine, error) {
+	mount = strings.Trim(mount, "/")
+
+	if cached, ok := v.kvVersionCache.Load(mount); ok {
+		return &KVEngine{v: v, mount: mount, version: cached.(string)}, nil
+	}
+
+	detected, err := detectKVVersion(v, mount)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := v.kvVersionCache.LoadOrStore(mount, detected)
+	return &KVEngine{v: v, mount: mount, version: actual.(string)}, nil
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// detectKVVersion reads sys/mounts and returns the options.version of the given mount, defaulting
+// to "1" when the mount doesn't advertise a version (as kv v1 mounts don't).
+func detectKVVersion(v *API, mount string) (string, error) {
+	data, err := v.Client.Logical().Read("sys/mounts")
+	if err != nil {
+		checkAuthErr := v.checkAuthNeeded()
+		if checkAuthErr == nil {
+			return detectKVVersion(v, mount)
+		}
+		return "", err
+	}
+
+	if data == nil {
+		return "", errors.New("unable to read sys/mounts")
+	}
+
+	mountConfig, ok := data.Data[mount+"/"]
+	if !ok {
+		return "", fmt.Errorf("mount not found: %s", mount)
+	}
+
+	mountData, ok := mountConfig.(map[string]interface{})
+	if !ok {
+		return "", errors.New("failed to work with data from sys/mounts")
+	}
+
+	options, ok := mountData["options"].(map[string]interface{})
+	if !ok || options["version"] == nil {
+		return "1", nil
+	}
+
+	version, ok := options["version"].(string)
+	if !ok {
+		return "1", nil
+	}
+
+	return version, nil
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// dataPath rewrites path to its kv-v2 data path (inserting /data/ after the mount), or leaves it
+// alone on a v1 mount.
+func (e *KVEngine) dataPath(path string) string {
+	if e.version != "2" {
+		return path
+	}
+	return e.rewrite(path, "data")
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// metadataPath rewrites path to its kv-v2 metadata path (inserting /metadata/ after the mount),
+// or leaves it alone on a v1 mount.
+func (e *KVEngine) metadataPath(path string) string {
+	if e.version != "2" {
+		return path
+	}
+	return e.rewrite(path, "metadata")
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+func (e *KVEngine) rewrite(path string, segment string) string {
+	path = strings.TrimPrefix(path, e.mount+"/")
+	return fmt.Sprintf("%s/%s/%s", e.mount, segment, path)
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Get reads the secret at path, unwrapping the data.data envelope on a kv-v2 mount.
+func (e *KVEngine) Get(path string) (map[string]interface{}, error) {
+	return e.v.GetKV(e.dataPath(path), e.version == "2")
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Put writes data to path, wrapping it in the data envelope a kv-v2 mount expects.
+func (e *KVEngine) Put(path string, data map[string]interface{}) (*vaultapi.Secret, error) {
+	if e.version != "2" {
+		return e.v.Write(e.dataPath(path), data)
+	}
+	return e.v.PutKV2(e.dataPath(path), data)
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Delete removes the secret at path.
+func (e *KVEngine) Delete(path string) error {
+	return e.v.Delete(e.dataPath(path))
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// List returns the keys under path.
+func (e *KVEngine) List(path string) ([]interface{}, error) {
+	return e.v.List(e.metadataPath(path))
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Versions returns the known versions for path, newest first. A v1 mount only ever has version 1.
+func (e *KVEngine) Versions(path string) ([]int, error) {
+	if e.version != "2" {
+		return []int{1}, nil
+	}
+	return e.v.GetKV2VersionList(e.metadataPath(path), false, false, false)
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// GetVersion reads version n of the secret at path.
+func (e *KVEngine) GetVersion(path string, n int) (map[string]interface{}, error) {
+	if e.version != "2" {
+		return e.Get(path)
+	}
+	return e.v.GetKV2ByVersion(e.dataPath(path), n)
+}
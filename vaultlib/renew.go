@@ -0,0 +1,170 @@
+package govault
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// renewalWindow is the minimum delay before a renewal attempt, and the cap on how much jitter is
+// subtracted from a computed delay, to avoid a thundering herd when many callers share a Vault.
+const renewalWindow = 30 * time.Second
+
+// renewalFraction is how far into a token's remaining TTL we schedule its next renewal attempt.
+const renewalFraction = 0.8
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// autoRenew holds the state of a background renewal loop started by StartAutoRenew.
+type autoRenew struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// StartAutoRenew launches a background goroutine that proactively keeps the current token alive
+// instead of waiting for a request to fail and reacting via checkAuthNeeded. It looks up the
+// token's TTL via auth/token/lookup-self, schedules a renewal at ~80% of that TTL (jittered
+// within renewalWindow), and calls Client.Auth().Token().RenewSelf at that deadline. If the token
+// isn't renewable (or the cached auth method is "token", which never is), it falls back to a full
+// re-login via the cached auth method instead. Call Stop to cancel the loop.
+func (v *API) StartAutoRenew(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	v.renewMu.Lock()
+	previous := v.renew
+	v.renew = &autoRenew{cancel: cancel, done: done}
+	v.renewMu.Unlock()
+
+	if previous != nil {
+		stopRenew(previous)
+	}
+
+	go func() {
+		defer close(done)
+		v.autoRenewLoop(ctx)
+	}()
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// Stop cancels the background loop started by StartAutoRenew and waits for it to exit.
+func (v *API) Stop() {
+	v.renewMu.Lock()
+	renew := v.renew
+	v.renew = nil
+	v.renewMu.Unlock()
+
+	if renew != nil {
+		stopRenew(renew)
+	}
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// stopRenew cancels a previously started renewal loop and waits for it to exit. It must not be
+// called while holding renewMu: the loop's own reauth calls take mu, and waiting on done here
+// only ever needs renewMu released first to avoid a pointless (if harmless) hand-off delay.
+func stopRenew(r *autoRenew) {
+	r.cancel()
+	<-r.done
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// autoRenewLoop tracks a single deadline for v's own token. There's no registration API for
+// additional or child tokens, so a min-heap of pending renewals would be dead weight; a plain
+// timer is all a single tracked deadline needs.
+func (v *API) autoRenewLoop(ctx context.Context) {
+	ttl, wait := v.nextRenewal()
+
+	for {
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			v.renewOrReauth(ctx, ttl)
+			ttl, wait = v.nextRenewal()
+		}
+	}
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// nextRenewal reads the current token's TTL and returns it alongside how long the caller should
+// sleep before its next renewal attempt. The returned ttl is what gets passed to RenewSelf as its
+// increment: Vault's renew-self treats increment as the target lease length, not an additive bump,
+// so using the TTL observed now (just after the last login/renewal, when the lease is freshest)
+// keeps each renewal close to the original lease length instead of handing RenewSelf whatever small
+// remainder is left by the time the renewal deadline arrives.
+func (v *API) nextRenewal() (int64, time.Duration) {
+	ttl, err := v.GetTokenTTL()
+	if err != nil || ttl < 0 {
+		ttl = 0
+	}
+
+	return ttl, renewDelay(ttl)
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// renewDelay computes the jittered delay until a token with the given TTL (in seconds) should be
+// renewed, based on renewalFraction of that TTL.
+func renewDelay(ttl int64) time.Duration {
+	if ttl <= 0 {
+		return renewalWindow
+	}
+
+	delay := time.Duration(float64(ttl)*renewalFraction) * time.Second
+	if delay <= renewalWindow {
+		return renewalWindow
+	}
+
+	delay -= time.Duration(rand.Int63n(int64(renewalWindow)))
+	if delay < renewalWindow {
+		return renewalWindow
+	}
+
+	return delay
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// renewOrReauth attempts to renew the current token using ttl (the value nextRenewal observed when
+// it scheduled this attempt) as the RenewSelf increment, falling back to a full re-login via the
+// cached auth method when the token isn't renewable. ctx is threaded through to every Vault call so
+// a hung request is abandoned as soon as Stop cancels it, instead of holding stopRenew's wait on
+// <-r.done for the full client timeout.
+func (v *API) renewOrReauth(ctx context.Context, ttl int64) {
+	lookup, err := v.Client.Auth().Token().LookupSelfWithContext(ctx)
+	renewable := err == nil && lookup != nil
+	if renewable {
+		renewable, _ = lookup.Data["renewable"].(bool)
+	}
+
+	if renewable {
+		if _, err := v.Client.Auth().Token().RenewSelfWithContext(ctx, int(ttl)); err != nil {
+			v.reauth(ctx)
+		}
+	} else {
+		v.reauth(ctx)
+	}
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// reauth forces a full re-login regardless of checkAuthNeeded's normal cooldown, since the
+// auto-renew loop already paces itself and shouldn't be skipped by that reactive-path guard. It
+// goes through reauthLocked (the same v.mu-guarded path checkAuthNeeded uses) so it can't race
+// with a concurrent checkAuthNeeded/login/SetToken call. ctx comes from autoRenewLoop so a Stop
+// call cancels a login in flight instead of waiting out its full timeout.
+func (v *API) reauth(ctx context.Context) {
+	_ = v.reauthLocked(ctx, true)
+}
@@ -0,0 +1,85 @@
+package govault
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// newUnreachableTestAPI returns an API pointed at an address nothing is listening on, so every
+// Vault call fails fast instead of hanging, with a bare token authenticator already set.
+func newUnreachableTestAPI(t *testing.T) *API {
+	t.Helper()
+
+	client, err := vaultapi.NewClient(&vaultapi.Config{Address: "http://127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("unable to create vault client: %s", err)
+	}
+	client.SetClientTimeout(50 * time.Millisecond)
+	client.SetToken("test-token")
+
+	return &API{Client: client, authenticator: &tokenAuthenticator{}}
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// TestConcurrentReauthAndCheckAuthNeeded exercises the same race the auto-renew loop and a
+// reactive List/Read/Write/Delete failure can hit in production: reauth (called from the
+// auto-renew loop) and checkAuthNeeded both read and write lastAuthTime. Run with -race.
+func TestConcurrentReauthAndCheckAuthNeeded(t *testing.T) {
+	v := newUnreachableTestAPI(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			v.reauth(context.Background())
+		}()
+
+		go func() {
+			defer wg.Done()
+			_ = v.checkAuthNeeded()
+		}()
+	}
+
+	wg.Wait()
+}
+
+/* ----------------------------------------------------------------------------------------------------------------- */
+
+// TestConcurrentLoginAndStartStopAutoRenew exercises login (used by every *Login method) racing
+// against StartAutoRenew/Stop, which read and write the renew field independently of mu. Run with
+// -race.
+func TestConcurrentLoginAndStartStopAutoRenew(t *testing.T) {
+	v := newUnreachableTestAPI(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+			_, _ = v.login(context.Background(), &tokenAuthenticator{})
+		}()
+
+		go func() {
+			defer wg.Done()
+			v.StartAutoRenew(context.Background())
+		}()
+
+		go func() {
+			defer wg.Done()
+			v.Stop()
+		}()
+	}
+
+	wg.Wait()
+	v.Stop()
+}
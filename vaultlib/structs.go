@@ -1,50 +1,32 @@
 package govault
 
 import (
+	"sync"
+
 	vaultapi "github.com/hashicorp/vault/api"
 )
 
 /* ----------------------------------------------------------------------------------------------------------------- */
 
-type vaultApproleAuth struct {
-	roleID       string
-	secretID     string
-	roleIDFile   string
-	secretIDFile string
-	tokenFile    string
-	authPath     string
-}
-
-/* ----------------------------------------------------------------------------------------------------------------- */
-
-type vaultKubernetesAuth struct {
-	jwt      string
-	role     string
-	authPath string
-}
-
-/* ----------------------------------------------------------------------------------------------------------------- */
-
-type vaultLDAPAuth struct {
-	username string
-	password string
-	authPath string
-}
-
-/* ----------------------------------------------------------------------------------------------------------------- */
-
-type vaultAuthCache struct {
-	kubernetes     vaultKubernetesAuth
-	approle        vaultApproleAuth
-	ldap           vaultLDAPAuth
-	lastAuthTime   int64
-	lastAuthMethod string
-}
-
-/* ----------------------------------------------------------------------------------------------------------------- */
-
 // API is the entrypoint for this module
 type API struct {
-	Client    *vaultapi.Client
-	authCache vaultAuthCache
+	Client *vaultapi.Client
+
+	// mu guards authenticator, lastAuthTime, and Client's token, ensuring concurrent callers never
+	// race on a re-auth and that at most one re-login is in flight at a time.
+	mu            sync.RWMutex
+	authenticator Authenticator
+	lastAuthTime  int64
+
+	// renewMu guards renew. It's deliberately separate from mu: Stop blocks on the renewal
+	// goroutine exiting, and that goroutine itself takes mu (via reauth), so sharing one mutex
+	// between the two would deadlock a Stop call against an in-flight renewal.
+	renewMu sync.Mutex
+	renew   *autoRenew
+
+	// kvVersionCache caches each mount's detected KV engine version, keyed by mount path, for
+	// NewKVEngine. It lives on the API rather than as a package-level global so that two APIs
+	// pointed at different clusters (or the same cluster under different namespaces, see
+	// WithNamespace) never share a cache entry for a mount path the two don't actually agree on.
+	kvVersionCache sync.Map
 }